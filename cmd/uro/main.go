@@ -1,10 +1,14 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"github.com/evilsocket/uroboros/export"
+	"github.com/evilsocket/uroboros/exporter"
 	"github.com/evilsocket/uroboros/host"
 	"github.com/evilsocket/uroboros/record"
+	"github.com/evilsocket/uroboros/trace"
 	ui "github.com/gizak/termui/v3"
 	"github.com/prometheus/procfs"
 	"os"
@@ -19,16 +23,34 @@ var err error
 var cpuProfile = ""
 var targetName = ""
 var refreshPeriod = 500
+var exporterAddr = ""
+var pidsList = ""
+var cgroupPath = ""
+var containerRef = ""
+var traceEnabled = false
+var exportFormat = ""
+var exportFile = ""
+var recordServerAddr = ""
 
 func init() {
 	flag.IntVar(&host.TargetPID, "pid", 0, "Process ID to monitor.")
 	flag.StringVar(&targetName, "search", "", "Search target process by name.")
+	flag.StringVar(&pidsList, "pids", pidsList, "Comma separated list of PIDs to monitor as a set, e.g. 1,2,3.")
+	flag.StringVar(&cgroupPath, "cgroup", cgroupPath, "Monitor every PID listed in this cgroup (e.g. /sys/fs/cgroup/...).")
+	flag.StringVar(&containerRef, "container", containerRef, "Monitor every PID belonging to this container id (name resolution is not supported).")
 	flag.IntVar(&refreshPeriod, "period", refreshPeriod, "Data refresh period in milliseconds.")
 	flag.StringVar(&host.ProcFS, "procfs", host.ProcFS, "Root of the proc filesystem.")
 	flag.StringVar(&tabIDS, "tabs", tabIDS, "Comma separated list of tab names to show.")
 
 	flag.StringVar(&recordFile, "record", recordFile, "If specified, record the session to this file.")
-	flag.StringVar(&replayFile, "replay", replayFile, "If specified, replay the session in this file.")
+	flag.StringVar(&replayFile, "replay", replayFile, "If specified, replay the session in this file, or stream one from a remote uroboros with tcp://host:port.")
+
+	flag.StringVar(&exportFormat, "export", exportFormat, "Export each tick alongside the session, in this format: jsonl or pcap.")
+	flag.StringVar(&exportFile, "export-file", exportFile, "File to write the -export output to.")
+	flag.StringVar(&recordServerAddr, "record-server", recordServerAddr, "If specified, also stream every tick to clients connecting over TCP on this address, for -replay tcp://host:port.")
+
+	flag.StringVar(&exporterAddr, "exporter", exporterAddr, "If specified, run headless and expose collected metrics as a Prometheus /metrics endpoint on this address (e.g. :9092).")
+	flag.BoolVar(&traceEnabled, "trace", traceEnabled, "Trace live retransmits, drops and socket latency via eBPF, falling back to procfs-only mode when unavailable.")
 
 	flag.StringVar(&cpuProfile, "cpu-profile", cpuProfile, "Used for debugging.")
 }
@@ -65,22 +87,83 @@ func searchTarget() {
 			} else {
 				for pid := range matches {
 					host.TargetPID = pid
-					return
+					break
 				}
 			}
 		}
 	}
 
+	if host.TargetPID > 0 {
+		host.AddTarget(host.TargetPID)
+	}
+
+	if pidsList != "" {
+		pids, err := host.ParsePIDList(pidsList)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		for _, pid := range pids {
+			host.AddTarget(pid)
+		}
+	}
+
+	if cgroupPath != "" {
+		pids, err := host.PIDsInCgroup(cgroupPath)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		for _, pid := range pids {
+			host.AddTarget(pid)
+		}
+	}
+
+	if containerRef != "" {
+		pids, err := host.PIDsInContainer(containerRef)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		for _, pid := range pids {
+			host.AddTarget(pid)
+		}
+	}
+
 	if host.TargetPID <= 0 {
 		host.TargetPID = os.Getpid()
 	}
+
+	if len(host.TargetPIDs) == 0 {
+		host.TargetPIDs = []int{host.TargetPID}
+	}
 }
 
+var tracer *trace.Tracer
+
 func main() {
 	flag.Parse()
 
 	searchTarget()
 
+	if traceEnabled {
+		if tracer, err = trace.Start(); err != nil {
+			fmt.Printf("retransmit/latency tracing disabled, falling back to procfs-only mode: %v\n", err)
+		} else {
+			defer tracer.Close()
+			exporter.SetTracer(tracer)
+		}
+	}
+
+	if exporterAddr != "" {
+		fmt.Printf("exposing metrics for pids %v on %s/metrics\n", host.TargetPIDs, exporterAddr)
+		if err = exporter.Serve(exporterAddr, time.Millisecond*time.Duration(refreshPeriod)); err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if cpuProfile != "" {
 		f, err := os.Create(cpuProfile)
 		if err != nil {
@@ -90,6 +173,11 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
+	if strings.HasPrefix(replayFile, "tcp://") {
+		replayRemote(strings.TrimPrefix(replayFile, "tcp://"))
+		return
+	}
+
 	if recordFile != "" {
 		if recorder, err = record.New(); err != nil {
 			fmt.Printf("%v\n", err)
@@ -102,6 +190,20 @@ func main() {
 		}
 	}
 
+	if exportFormat != "" {
+		if err = setupExportWriter(); err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if recordServerAddr != "" {
+		if remoteTicks, err = export.ServeRemote(recordServerAddr); err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	if err = setupUI(host.TargetPID); err != nil {
 		fmt.Printf("%v\n", err)
 		os.Exit(1)
@@ -122,6 +224,7 @@ func main() {
 		select {
 		case <-ticker:
 			updateTabs()
+			exportTick()
 
 		case e := <-uiEvents:
 			switch e.ID {
@@ -152,3 +255,96 @@ func main() {
 			updateUI()
 	}
 }
+
+var (
+	jsonlWriter *export.JSONLWriter
+	pcapWriter  *export.PCAPWriter
+	remoteTicks chan<- export.Tick
+)
+
+// setupExportWriter opens exportFile and prepares the writer matching
+// exportFormat ("jsonl" or "pcap").
+func setupExportWriter() error {
+	if exportFile == "" {
+		exportFile = "uroboros." + exportFormat
+	}
+
+	f, err := os.Create(exportFile)
+	if err != nil {
+		return err
+	}
+
+	switch exportFormat {
+	case "jsonl":
+		jsonlWriter = export.NewJSONLWriter(f)
+	case "pcap":
+		if pcapWriter, err = export.NewPCAPWriter(f); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown -export format '%s', expected jsonl or pcap", exportFormat)
+	}
+
+	return nil
+}
+
+// exportTick collects the current process and network state and feeds it
+// to whichever exporters (-export, -record-server) are active.
+func exportTick() {
+	if jsonlWriter == nil && pcapWriter == nil && remoteTicks == nil {
+		return
+	}
+
+	pids := host.TargetPIDs
+	if len(pids) == 0 {
+		pids = []int{host.TargetPID}
+	}
+
+	metrics := make([]host.ProcessMetrics, 0, len(pids))
+	for _, pid := range pids {
+		if m, err := host.CollectProcessMetrics(pid); err == nil {
+			metrics = append(metrics, m)
+		}
+	}
+
+	network, err := host.CollectNetwork()
+	if err != nil {
+		return
+	}
+
+	entries := make([]host.NetworkEntry, 0, len(network))
+	for _, entry := range network {
+		entries = append(entries, entry)
+	}
+
+	tick := export.Tick{Time: time.Now(), Metrics: metrics, Network: entries}
+
+	if jsonlWriter != nil {
+		jsonlWriter.Write(tick)
+	}
+	if pcapWriter != nil {
+		pcapWriter.WriteTick(entries, tick.Time)
+	}
+	if remoteTicks != nil {
+		remoteTicks <- tick
+	}
+}
+
+// replayRemote streams a live session from a uroboros instance running
+// with -record-server, printing each tick as JSON to stdout for headless
+// remote diagnosis.
+func replayRemote(addr string) {
+	ticks, err := export.DialRemote(addr)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+
+	for tick := range ticks {
+		data, err := json.Marshal(tick)
+		if err != nil {
+			continue
+		}
+		fmt.Println(string(data))
+	}
+}
@@ -0,0 +1,39 @@
+// Package export provides first-class session exporters that complement
+// the opaque binary format used by the -record/-replay flags: -export
+// jsonl streams each tick as newline-delimited JSON for jq/pandas, and
+// -export pcap synthesizes a pcap of observed TCP/UDP flows. It also
+// implements a small TCP protocol for -record-server / -replay tcp://,
+// allowing a live session to be streamed to a remote uroboros for
+// headless diagnosis.
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/evilsocket/uroboros/host"
+)
+
+// Tick is a single sample of collected state, as streamed to JSONL
+// exports and remote replay clients.
+type Tick struct {
+	Time    time.Time             `json:"time"`
+	Metrics []host.ProcessMetrics `json:"metrics"`
+	Network []host.NetworkEntry   `json:"network"`
+}
+
+// JSONLWriter streams Ticks as newline-delimited JSON, one line per tick.
+type JSONLWriter struct {
+	enc *json.Encoder
+}
+
+// NewJSONLWriter wraps w as a JSONLWriter.
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	return &JSONLWriter{enc: json.NewEncoder(w)}
+}
+
+// Write encodes tick as a single JSON line.
+func (w *JSONLWriter) Write(tick Tick) error {
+	return w.enc.Encode(tick)
+}
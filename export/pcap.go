@@ -0,0 +1,242 @@
+package export
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"time"
+
+	"github.com/evilsocket/uroboros/host"
+)
+
+const (
+	pcapMagic      = 0xa1b2c3d4
+	pcapVersionMaj = 2
+	pcapVersionMin = 4
+	linkTypeRaw    = 101 // DLT_RAW: raw IP, no link layer framing
+)
+
+// PCAPWriter synthesizes SYN/SYN-ACK/FIN/RST packets from TCP NetworkEntry
+// state transitions, plus one best-effort datagram per UDP socket seen, so
+// a recorded session can be opened directly in Wireshark without a real
+// packet capture having been taken.
+type PCAPWriter struct {
+	w    io.Writer
+	prev map[host.NetworkINodeKey]uint // previous TCP state by socket, to detect transitions
+}
+
+// NewPCAPWriter writes a pcap global header to w and returns a PCAPWriter
+// ready to receive ticks.
+func NewPCAPWriter(w io.Writer) (*PCAPWriter, error) {
+	pw := &PCAPWriter{w: w, prev: make(map[host.NetworkINodeKey]uint)}
+	return pw, pw.writeGlobalHeader()
+}
+
+func (pw *PCAPWriter) writeGlobalHeader() error {
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint32(header[0:], pcapMagic)
+	binary.LittleEndian.PutUint16(header[4:], pcapVersionMaj)
+	binary.LittleEndian.PutUint16(header[6:], pcapVersionMin)
+	binary.LittleEndian.PutUint32(header[16:], 65535) // snaplen
+	binary.LittleEndian.PutUint32(header[20:], linkTypeRaw)
+	_, err := pw.w.Write(header)
+	return err
+}
+
+// WriteTick inspects entries for TCP state transitions and previously
+// unseen UDP sockets since the last call, and synthesizes the
+// corresponding packet for each. Sockets are identified by their
+// NetNSInode+INode pair rather than the bare inode, since two sockets in
+// different network namespaces (see -pids/-cgroup/-container) can reuse
+// the same inode number.
+func (pw *PCAPWriter) WriteTick(entries []host.NetworkEntry, at time.Time) error {
+	for _, entry := range entries {
+		key := host.NetworkINodeKey{NetNSInode: entry.NetNSInode, INode: entry.INode}
+
+		switch entry.Proto {
+		case "tcp", "tcp6":
+			prev, seen := pw.prev[key]
+			pw.prev[key] = entry.State
+
+			if !seen || prev == entry.State {
+				continue
+			}
+
+			flags, ok := transitionFlags(prev, entry.State)
+			if !ok {
+				continue
+			}
+
+			if err := pw.writeTCPPacket(entry, flags, at); err != nil {
+				return err
+			}
+
+		case "udp", "udp6":
+			// UDP is connectionless, so there's no state transition to key
+			// off of: synthesize one best-effort datagram the first time a
+			// socket is observed.
+			if _, seen := pw.prev[key]; seen {
+				continue
+			}
+			pw.prev[key] = 0
+
+			if err := pw.writeUDPPacket(entry, at); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// transitionFlags maps a TCP state transition to the TCP flags of the
+// packet that would have caused it.
+func transitionFlags(from, to uint) (uint8, bool) {
+	switch to {
+	case host.TCP_SYN_SENT:
+		return tcpSYN, true
+	case host.TCP_SYN_RECV:
+		return tcpSYN | tcpACK, true
+	case host.TCP_ESTABLISHED:
+		if from == host.TCP_SYN_SENT {
+			return tcpSYN | tcpACK, true
+		}
+		return tcpACK, true
+	case host.TCP_FIN_WAIT1, host.TCP_CLOSE_WAIT:
+		return tcpFIN | tcpACK, true
+	case host.TCP_TIME_WAIT, host.TCP_LAST_ACK:
+		return tcpFIN | tcpACK, true
+	case host.TCP_CLOSE:
+		return tcpRST, true
+	default:
+		return 0, false
+	}
+}
+
+const (
+	tcpFIN uint8 = 1 << 0
+	tcpSYN uint8 = 1 << 1
+	tcpRST uint8 = 1 << 2
+	tcpACK uint8 = 1 << 4
+)
+
+func (pw *PCAPWriter) writeTCPPacket(entry host.NetworkEntry, flags uint8, at time.Time) error {
+	pkt := buildIPv4TCPPacket(entry.SrcIP, entry.DstIP, uint16(entry.SrcPort), uint16(entry.DstPort), flags)
+	return pw.writeRecord(pkt, at)
+}
+
+func (pw *PCAPWriter) writeUDPPacket(entry host.NetworkEntry, at time.Time) error {
+	pkt := buildIPv4UDPPacket(entry.SrcIP, entry.DstIP, uint16(entry.SrcPort), uint16(entry.DstPort))
+	return pw.writeRecord(pkt, at)
+}
+
+func (pw *PCAPWriter) writeRecord(pkt []byte, at time.Time) error {
+	record := make([]byte, 16)
+	secs := at.Unix()
+	usecs := at.Nanosecond() / 1000
+	binary.LittleEndian.PutUint32(record[0:], uint32(secs))
+	binary.LittleEndian.PutUint32(record[4:], uint32(usecs))
+	binary.LittleEndian.PutUint32(record[8:], uint32(len(pkt)))
+	binary.LittleEndian.PutUint32(record[12:], uint32(len(pkt)))
+
+	if _, err := pw.w.Write(record); err != nil {
+		return err
+	}
+	_, err := pw.w.Write(pkt)
+	return err
+}
+
+// buildIPv4TCPPacket assembles a minimal, checksummed, payload-less IPv4
+// TCP segment with the given flags.
+func buildIPv4TCPPacket(src, dst []byte, srcPort, dstPort uint16, flags uint8) []byte {
+	src4 := to4(src)
+	dst4 := to4(dst)
+
+	tcp := make([]byte, 20)
+	binary.BigEndian.PutUint16(tcp[0:], srcPort)
+	binary.BigEndian.PutUint16(tcp[2:], dstPort)
+	tcp[12] = 5 << 4 // data offset, no options
+	tcp[13] = flags
+	binary.BigEndian.PutUint16(tcp[14:], 65535) // window
+
+	binary.BigEndian.PutUint16(tcp[16:], tcpChecksum(src4, dst4, tcp))
+
+	ip := make([]byte, 20)
+	ip[0] = 4<<4 | 5
+	binary.BigEndian.PutUint16(ip[2:], uint16(len(ip)+len(tcp)))
+	ip[8] = 64 // ttl
+	ip[9] = 6  // protocol: TCP
+	copy(ip[12:16], src4)
+	copy(ip[16:20], dst4)
+	binary.BigEndian.PutUint16(ip[10:], ipChecksum(ip))
+
+	return append(ip, tcp...)
+}
+
+// buildIPv4UDPPacket assembles a minimal, checksummed, payload-less IPv4
+// UDP datagram.
+func buildIPv4UDPPacket(src, dst []byte, srcPort, dstPort uint16) []byte {
+	src4 := to4(src)
+	dst4 := to4(dst)
+
+	udp := make([]byte, 8)
+	binary.BigEndian.PutUint16(udp[0:], srcPort)
+	binary.BigEndian.PutUint16(udp[2:], dstPort)
+	binary.BigEndian.PutUint16(udp[4:], uint16(len(udp)))
+	binary.BigEndian.PutUint16(udp[6:], udpChecksum(src4, dst4, udp))
+
+	ip := make([]byte, 20)
+	ip[0] = 4<<4 | 5
+	binary.BigEndian.PutUint16(ip[2:], uint16(len(ip)+len(udp)))
+	ip[8] = 64 // ttl
+	ip[9] = 17 // protocol: UDP
+	copy(ip[12:16], src4)
+	copy(ip[16:20], dst4)
+	binary.BigEndian.PutUint16(ip[10:], ipChecksum(ip))
+
+	return append(ip, udp...)
+}
+
+func to4(ip []byte) []byte {
+	if v4 := net.IP(ip).To4(); v4 != nil {
+		return v4
+	}
+	return []byte{0, 0, 0, 0}
+}
+
+func ipChecksum(header []byte) uint16 {
+	return checksum(header)
+}
+
+func tcpChecksum(src, dst []byte, tcp []byte) uint16 {
+	pseudo := make([]byte, 12+len(tcp))
+	copy(pseudo[0:4], src)
+	copy(pseudo[4:8], dst)
+	pseudo[9] = 6 // protocol: TCP
+	binary.BigEndian.PutUint16(pseudo[10:12], uint16(len(tcp)))
+	copy(pseudo[12:], tcp)
+	return checksum(pseudo)
+}
+
+func udpChecksum(src, dst []byte, udp []byte) uint16 {
+	pseudo := make([]byte, 12+len(udp))
+	copy(pseudo[0:4], src)
+	copy(pseudo[4:8], dst)
+	pseudo[9] = 17 // protocol: UDP
+	binary.BigEndian.PutUint16(pseudo[10:12], uint16(len(udp)))
+	copy(pseudo[12:], udp)
+	return checksum(pseudo)
+}
+
+func checksum(data []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(data); i += 2 {
+		sum += uint32(data[i])<<8 | uint32(data[i+1])
+	}
+	if len(data)%2 == 1 {
+		sum += uint32(data[len(data)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = (sum & 0xffff) + (sum >> 16)
+	}
+	return ^uint16(sum)
+}
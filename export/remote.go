@@ -0,0 +1,98 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+)
+
+// ServeRemote listens on addr and streams every Tick sent on the returned
+// channel, newline-delimited JSON, to every connected client. It backs
+// the -record-server flag, letting a remote `uroboros -replay
+// tcp://host:port` follow a live session.
+func ServeRemote(addr string) (chan<- Tick, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	ticks := make(chan Tick, 64)
+	clients := make(chan net.Conn)
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			clients <- conn
+		}
+	}()
+
+	go broadcast(ticks, clients)
+
+	return ticks, nil
+}
+
+func broadcast(ticks <-chan Tick, clients <-chan net.Conn) {
+	var conns []net.Conn
+
+	for {
+		select {
+		case conn := <-clients:
+			conns = append(conns, conn)
+
+		case tick, ok := <-ticks:
+			if !ok {
+				for _, conn := range conns {
+					conn.Close()
+				}
+				return
+			}
+
+			data, err := json.Marshal(tick)
+			if err != nil {
+				continue
+			}
+			data = append(data, '\n')
+
+			live := conns[:0]
+			for _, conn := range conns {
+				if _, err := conn.Write(data); err == nil {
+					live = append(live, conn)
+				} else {
+					conn.Close()
+				}
+			}
+			conns = live
+		}
+	}
+}
+
+// DialRemote connects to a uroboros instance running with
+// -record-server and yields each Tick as it streams in. The returned
+// channel is closed when the connection ends.
+func DialRemote(addr string) (<-chan Tick, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	ticks := make(chan Tick)
+
+	go func() {
+		defer close(ticks)
+		defer conn.Close()
+
+		dec := json.NewDecoder(bufio.NewReader(conn))
+		for {
+			var tick Tick
+			if err := dec.Decode(&tick); err != nil {
+				return
+			}
+			ticks <- tick
+		}
+	}()
+
+	return ticks, nil
+}
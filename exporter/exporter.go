@@ -0,0 +1,153 @@
+// Package exporter exposes the process and network metrics collected by
+// the host package over HTTP in Prometheus text exposition format, so
+// uroboros can run headless as a scrape target alongside the TUI mode.
+package exporter
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/evilsocket/uroboros/host"
+	"github.com/evilsocket/uroboros/trace"
+)
+
+type snapshot struct {
+	metrics []host.ProcessMetrics
+	network host.NetworkINodes
+}
+
+var (
+	mu      sync.RWMutex
+	current snapshot
+	tracer  *trace.Tracer
+)
+
+// SetTracer makes the exporter also report per-connection retransmit,
+// drop and latency counters collected by t, labelled by socket inode.
+// Passing nil disables that section of /metrics.
+func SetTracer(t *trace.Tracer) {
+	tracer = t
+}
+
+func collect() {
+	pids := host.TargetPIDs
+	if len(pids) == 0 {
+		pids = []int{host.TargetPID}
+	}
+
+	metrics := make([]host.ProcessMetrics, 0, len(pids))
+	for _, pid := range pids {
+		if m, err := host.CollectProcessMetrics(pid); err == nil {
+			metrics = append(metrics, m)
+		}
+	}
+
+	network, err := host.CollectNetwork()
+	if err != nil {
+		return
+	}
+
+	mu.Lock()
+	current = snapshot{metrics: metrics, network: network}
+	mu.Unlock()
+}
+
+// Serve starts an HTTP server on addr exposing /metrics, refreshing the
+// underlying collectors every period. It blocks until the server stops.
+func Serve(addr string, period time.Duration) error {
+	collect()
+
+	go func() {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for range ticker.C {
+			collect()
+		}
+	}()
+
+	http.HandleFunc("/metrics", handleMetrics)
+
+	return http.ListenAndServe(addr, nil)
+}
+
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	mu.RLock()
+	snap := current
+	mu.RUnlock()
+
+	writeGaugeHeader(w, "uroboros_cpu_seconds_total", "counter", "Total CPU time consumed by each monitored process.")
+	for _, m := range snap.metrics {
+		writeGaugeLine(w, "uroboros_cpu_seconds_total", fmt.Sprintf("pid=\"%d\"", m.PID), m.CPUTimeTotal)
+	}
+
+	writeGaugeHeader(w, "uroboros_resident_memory_bytes", "gauge", "Resident set size of each monitored process.")
+	for _, m := range snap.metrics {
+		writeGaugeLine(w, "uroboros_resident_memory_bytes", fmt.Sprintf("pid=\"%d\"", m.PID), float64(m.ResidentBytes))
+	}
+
+	writeGaugeHeader(w, "uroboros_virtual_memory_bytes", "gauge", "Virtual memory size of each monitored process.")
+	for _, m := range snap.metrics {
+		writeGaugeLine(w, "uroboros_virtual_memory_bytes", fmt.Sprintf("pid=\"%d\"", m.PID), float64(m.VirtualBytes))
+	}
+
+	writeGaugeHeader(w, "uroboros_io_read_bytes_total", "counter", "Bytes read from storage by each monitored process.")
+	for _, m := range snap.metrics {
+		writeGaugeLine(w, "uroboros_io_read_bytes_total", fmt.Sprintf("pid=\"%d\"", m.PID), float64(m.ReadBytes))
+	}
+
+	writeGaugeHeader(w, "uroboros_io_write_bytes_total", "counter", "Bytes written to storage by each monitored process.")
+	for _, m := range snap.metrics {
+		writeGaugeLine(w, "uroboros_io_write_bytes_total", fmt.Sprintf("pid=\"%d\"", m.PID), float64(m.WriteBytes))
+	}
+
+	writeGaugeHeader(w, "uroboros_open_fds", "gauge", "Number of open file descriptors of each monitored process.")
+	for _, m := range snap.metrics {
+		writeGaugeLine(w, "uroboros_open_fds", fmt.Sprintf("pid=\"%d\"", m.PID), float64(m.OpenFDs))
+	}
+
+	writeGaugeHeader(w, "uroboros_threads", "gauge", "Number of threads (scheduler tasks) of each monitored process.")
+	for _, m := range snap.metrics {
+		writeGaugeLine(w, "uroboros_threads", fmt.Sprintf("pid=\"%d\"", m.PID), float64(m.Threads))
+	}
+
+	fmt.Fprintln(w, "# HELP uroboros_socket_state_total Number of sockets observed per protocol and state.")
+	fmt.Fprintln(w, "# TYPE uroboros_socket_state_total gauge")
+
+	counts := make(map[[2]string]int)
+	for _, entry := range snap.network {
+		counts[[2]string{entry.Proto, entry.StateString}]++
+	}
+	for key, count := range counts {
+		fmt.Fprintf(w, "uroboros_socket_state_total{proto=%q,state=%q} %d\n", key[0], key[1], count)
+	}
+
+	if tracer == nil {
+		return
+	}
+
+	writeGaugeHeader(w, "uroboros_tcp_retransmits_total", "counter", "TCP retransmits observed per connection, via eBPF tracing.")
+	for inode, stats := range tracer.Stats() {
+		writeGaugeLine(w, "uroboros_tcp_retransmits_total", fmt.Sprintf("inode=\"%d\"", inode), float64(stats.Retransmits))
+	}
+
+	writeGaugeHeader(w, "uroboros_tcp_drops_total", "counter", "TCP packet drops observed per connection, via eBPF tracing.")
+	for inode, stats := range tracer.Stats() {
+		writeGaugeLine(w, "uroboros_tcp_drops_total", fmt.Sprintf("inode=\"%d\"", inode), float64(stats.Drops))
+	}
+
+	writeGaugeHeader(w, "uroboros_tcp_latency_ns", "gauge", "Most recent send-to-ack latency sample per connection, via eBPF tracing.")
+	for inode, stats := range tracer.Stats() {
+		writeGaugeLine(w, "uroboros_tcp_latency_ns", fmt.Sprintf("inode=\"%d\"", inode), float64(stats.LatencyNs))
+	}
+}
+
+func writeGaugeHeader(w http.ResponseWriter, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+func writeGaugeLine(w http.ResponseWriter, name, labels string, value float64) {
+	fmt.Fprintf(w, "%s{%s} %f\n", name, labels, value)
+}
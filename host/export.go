@@ -0,0 +1,61 @@
+package host
+
+import (
+	"os"
+
+	"github.com/prometheus/procfs"
+)
+
+// ProcessMetrics is a snapshot of the per-process counters exposed by the
+// Prometheus exporter: CPU time, memory footprint, IO and open file
+// descriptors, all read straight from procfs for a single PID.
+type ProcessMetrics struct {
+	PID           int
+	CPUTimeTotal  float64
+	ResidentBytes uint64
+	VirtualBytes  uint64
+	ReadBytes     uint64
+	WriteBytes    uint64
+	OpenFDs       uint64
+	Threads       int64
+}
+
+// CollectProcessMetrics gathers CPU, memory, IO and FD counters for pid
+// directly from procfs, for consumption by the Prometheus exporter.
+func CollectProcessMetrics(pid int) (ProcessMetrics, error) {
+	proc, err := procfs.NewProc(pid)
+	if err != nil {
+		return ProcessMetrics{}, err
+	}
+
+	stat, err := proc.Stat()
+	if err != nil {
+		return ProcessMetrics{}, err
+	}
+
+	metrics := ProcessMetrics{
+		PID:           pid,
+		CPUTimeTotal:  stat.CPUTime(),
+		VirtualBytes:  uint64(stat.VSize),
+		ResidentBytes: uint64(stat.RSS) * uint64(os.Getpagesize()),
+		Threads:       int64(stat.NumThreads),
+	}
+
+	if io, err := proc.IO(); err == nil {
+		metrics.ReadBytes = io.ReadBytes
+		metrics.WriteBytes = io.WriteBytes
+	}
+
+	if fds, err := proc.FileDescriptorsLen(); err == nil {
+		metrics.OpenFDs = uint64(fds)
+	}
+
+	return metrics, nil
+}
+
+// CollectNetwork returns every socket entry currently visible for the
+// monitored target(s), keyed by network namespace and inode, for the Prometheus exporter and
+// the network tab.
+func CollectNetwork() (NetworkINodes, error) {
+	return parseNetworkInodes()
+}
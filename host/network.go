@@ -6,10 +6,19 @@ import (
 	"github.com/evilsocket/islazy/str"
 	"net"
 	"os"
+	"strconv"
 	"strings"
 )
 
-type NetworkINodes map[int]NetworkEntry
+// NetworkINodeKey identifies a socket within a specific network namespace,
+// since the same inode number can be reused across namespaces once
+// multiple targets (see TargetPIDs) are monitored at once.
+type NetworkINodeKey struct {
+	NetNSInode uint64
+	INode      int
+}
+
+type NetworkINodes map[NetworkINodeKey]NetworkEntry
 
 // https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git/tree/include/net/tcp_states.h
 const (
@@ -62,12 +71,20 @@ type NetworkEntry struct {
 	DstPort     uint
 	UserId      int
 	INode       int
+	NetNSInode  uint64
+	PeerPID     int
+	PeerComm    string
 }
 
 func (e NetworkEntry) String() string {
 	if e.Proto == "unix" {
 		// see about empty paths: https://stackoverflow.com/questions/820782/how-do-i-find-out-what-programs-on-the-other-end-of-a-local-socket
+		// resolved below via resolvePeers, which finds the real peer inode
+		// and looks up its owning process
 		if e.Path == "" {
+			if e.PeerComm != "" {
+				return fmt.Sprintf("(%s) %s %d/%s", e.Proto, e.TypeString, e.PeerPID, e.PeerComm)
+			}
 			return fmt.Sprintf("(%s) %s inode=%d", e.Proto, e.TypeString, e.INode)
 		}
 		return fmt.Sprintf("(%s) %s path='%s'", e.Proto, e.TypeString, e.Path)
@@ -81,6 +98,10 @@ func (e NetworkEntry) String() string {
 		return fmt.Sprintf("(%s) %s:%d", e.Proto, e.SrcIP, e.SrcPort)
 	}
 
+	if e.SrcIP.IsLoopback() && e.PeerComm != "" {
+		return fmt.Sprintf("(%s) %s:%d <-> %d/%s", e.Proto, e.SrcIP, e.SrcPort, e.PeerPID, e.PeerComm)
+	}
+
 	return fmt.Sprintf("(%s) %s:%d <-> %s:%d", e.Proto, e.SrcIP, e.SrcPort, e.DstIP, e.DstPort)
 }
 
@@ -180,9 +201,12 @@ func parseNetlink(filename, line, protocol string) (entry NetworkEntry, err erro
 	return entry, nil
 }
 
-// Parse scans and retrieves the opened connections, from /proc/net/ files
-func parseNetworkForProtocol(proto string) ([]NetworkEntry, error) {
-	filename := fmt.Sprintf("%s/net/%s", ProcFS, proto)
+// Parse scans and retrieves the opened connections of a target process,
+// from its /proc/<pid>/net/ files rather than the global /proc/net/ ones,
+// so sockets owned by a process in a different network namespace (e.g. a
+// container) are visible.
+func parseNetworkForProtocol(pid int, proto string) ([]NetworkEntry, error) {
+	filename := fmt.Sprintf("%s/%d/net/%s", ProcFS, pid, proto)
 	fd, err := os.Open(filename)
 	if err != nil {
 		return nil, err
@@ -221,15 +245,161 @@ func parseNetworkForProtocol(proto string) ([]NetworkEntry, error) {
 }
 
 func parseNetworkInodes() (NetworkINodes, error) {
+	pids := TargetPIDs
+	if len(pids) == 0 {
+		pids = []int{TargetPID}
+	}
+
 	byInode := make(NetworkINodes)
-	for i := range protocols {
-		if entries, err := parseNetworkForProtocol(protocols[i]); err != nil {
-			return nil, err
-		} else {
+	for _, pid := range pids {
+		netns, _ := netNSInode(pid)
+
+		for i := range protocols {
+			// a single target having exited, or one proto being
+			// transiently unreadable, shouldn't blank out every other
+			// target's sockets for this tick
+			entries, err := parseNetworkForProtocol(pid, protocols[i])
+			if err != nil {
+				continue
+			}
+
 			for _, entry := range entries {
-				byInode[entry.INode] = entry
+				entry.NetNSInode = netns
+				byInode[NetworkINodeKey{NetNSInode: netns, INode: entry.INode}] = entry
 			}
 		}
 	}
+
+	resolvePeers(byInode)
+
 	return byInode, nil
 }
+
+// netConnKey identifies one side of a TCP connection within a network
+// namespace, used to find the row for the *other* side.
+type netConnKey struct {
+	netns   uint64
+	proto   string
+	srcIP   string
+	srcPort uint
+	dstIP   string
+	dstPort uint
+}
+
+// resolvePeers fills in PeerPID/PeerComm for local sockets: unix sockets
+// via their real peer inode (from NETLINK_SOCK_DIAG, since /proc/net/unix
+// lists each end as an unlinked row), and loopback TCP connections by
+// finding the row whose src/dst is this one reversed — each end of a
+// loopback connection has its own distinct inode, so inodeOwners(this
+// entry's own INode) would only ever resolve back to this same row's
+// owner, not the process on the other end.
+func resolvePeers(byInode NetworkINodes) {
+	owners, err := inodeOwners()
+	if err != nil {
+		return
+	}
+
+	unixPeers, _ := unixPeerINodes() // nil on error/unsupported platform; just skip unix peers then
+
+	bySrcDst := make(map[netConnKey]int, len(byInode))
+	for _, entry := range byInode {
+		if entry.Proto != "tcp" && entry.Proto != "tcp6" {
+			continue
+		}
+		bySrcDst[netConnKey{
+			netns: entry.NetNSInode, proto: entry.Proto,
+			srcIP: entry.SrcIP.String(), srcPort: entry.SrcPort,
+			dstIP: entry.DstIP.String(), dstPort: entry.DstPort,
+		}] = entry.INode
+	}
+
+	for key, entry := range byInode {
+		var peerINode int
+		var havePeer bool
+
+		switch {
+		case entry.Proto == "unix":
+			peerINode, havePeer = unixPeers[entry.INode]
+
+		case (entry.Proto == "tcp" || entry.Proto == "tcp6") && entry.SrcIP.IsLoopback():
+			peerINode, havePeer = bySrcDst[netConnKey{
+				netns: entry.NetNSInode, proto: entry.Proto,
+				srcIP: entry.DstIP.String(), srcPort: entry.DstPort,
+				dstIP: entry.SrcIP.String(), dstPort: entry.SrcPort,
+			}]
+		}
+
+		if !havePeer {
+			continue
+		}
+
+		if pid, ok := owners[peerINode]; ok {
+			entry.PeerPID = pid
+			entry.PeerComm = commOf(pid)
+			byInode[key] = entry
+		}
+	}
+}
+
+// inodeOwners scans /proc/*/fd/* symlinks and returns a map from socket
+// inode to the PID holding an open file descriptor for it.
+func inodeOwners() (map[int]int, error) {
+	entries, err := os.ReadDir(ProcFS)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := make(map[int]int)
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		fdDir := fmt.Sprintf("%s/%d/fd", ProcFS, pid)
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+
+		for _, fd := range fds {
+			link, err := os.Readlink(fmt.Sprintf("%s/%s", fdDir, fd.Name()))
+			if err != nil {
+				continue
+			}
+
+			var inode int
+			if _, err := fmt.Sscanf(link, "socket:[%d]", &inode); err == nil {
+				owners[inode] = pid
+			}
+		}
+	}
+
+	return owners, nil
+}
+
+// commOf returns pid's command name from /proc/<pid>/comm, or "" if it
+// can no longer be read (e.g. the process has since exited).
+func commOf(pid int) string {
+	data, err := os.ReadFile(fmt.Sprintf("%s/%d/comm", ProcFS, pid))
+	if err != nil {
+		return ""
+	}
+	return str.Trim(string(data))
+}
+
+// netNSInode returns the inode identifying pid's network namespace, read
+// from the /proc/<pid>/ns/net symlink (e.g. "net:[4026531840]").
+func netNSInode(pid int) (uint64, error) {
+	link, err := os.Readlink(fmt.Sprintf("%s/%d/ns/net", ProcFS, pid))
+	if err != nil {
+		return 0, err
+	}
+
+	var inode uint64
+	if _, err := fmt.Sscanf(link, "net:[%d]", &inode); err != nil {
+		return 0, fmt.Errorf("could not parse net namespace inode from '%s'", link)
+	}
+
+	return inode, nil
+}
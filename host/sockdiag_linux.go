@@ -0,0 +1,133 @@
+//go:build linux
+
+package host
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// unixPeerINodes queries NETLINK_SOCK_DIAG for every UNIX domain socket's
+// peer inode. /proc/net/unix lists each end of a connected pair as an
+// independent row with no field linking them, so this is the only way to
+// correlate an anonymous unix socket back to the inode on the other end.
+func unixPeerINodes() (map[int]int, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW, unix.NETLINK_SOCK_DIAG)
+	if err != nil {
+		return nil, err
+	}
+	defer unix.Close(fd)
+
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return nil, err
+	}
+
+	if err := unix.Sendto(fd, unixDiagDumpRequest(), 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return nil, err
+	}
+
+	peers := make(map[int]int)
+	buf := make([]byte, 1<<16)
+
+	for {
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		done, err := parseUnixDiagDump(buf[:n], peers)
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			return peers, nil
+		}
+	}
+}
+
+// unix_diag_req, see include/uapi/linux/unix_diag.h
+const (
+	sockDiagByFamily = 20 // SOCK_DIAG_BY_FAMILY
+	udiagShowPeer    = 0x04
+	unixDiagPeer     = 2 // UNIX_DIAG_PEER attribute type
+)
+
+func unixDiagDumpRequest() []byte {
+	const (
+		nlmsghdrLen    = 16
+		unixDiagReqLen = 24
+	)
+
+	buf := make([]byte, nlmsghdrLen+unixDiagReqLen)
+
+	// nlmsghdr
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(buf)))
+	binary.LittleEndian.PutUint16(buf[4:6], sockDiagByFamily)
+	binary.LittleEndian.PutUint16(buf[6:8], unix.NLM_F_REQUEST|unix.NLM_F_DUMP)
+	// seq (8:12) and pid (12:16) left zero
+
+	// unix_diag_req
+	req := buf[nlmsghdrLen:]
+	req[0] = unix.AF_UNIX // sdiag_family
+	req[1] = 0            // sdiag_protocol
+	// pad (2:4)
+	binary.LittleEndian.PutUint32(req[4:8], 0xffffffff) // udiag_states: every state
+	binary.LittleEndian.PutUint32(req[8:12], 0)         // udiag_ino: all sockets
+	binary.LittleEndian.PutUint32(req[12:16], udiagShowPeer)
+	// udiag_cookie (16:24) left zero: no specific cookie
+
+	return buf
+}
+
+// parseUnixDiagDump walks the netlink messages in buf, recording each
+// socket's peer inode in peers. It returns true once NLMSG_DONE is seen.
+func parseUnixDiagDump(buf []byte, peers map[int]int) (bool, error) {
+	const (
+		nlmsghdrLen    = 16
+		unixDiagMsgLen = 16
+		rtattrLen      = 4
+	)
+
+	for len(buf) >= nlmsghdrLen {
+		msgLen := binary.LittleEndian.Uint32(buf[0:4])
+		msgType := binary.LittleEndian.Uint16(buf[4:6])
+		if msgLen < nlmsghdrLen || int(msgLen) > len(buf) {
+			return false, fmt.Errorf("malformed netlink message")
+		}
+
+		switch msgType {
+		case unix.NLMSG_DONE:
+			return true, nil
+		case unix.NLMSG_ERROR:
+			return false, fmt.Errorf("NETLINK_SOCK_DIAG returned an error")
+		}
+
+		body := buf[nlmsghdrLen:msgLen]
+		if len(body) >= unixDiagMsgLen {
+			ino := int(binary.LittleEndian.Uint32(body[4:8]))
+			attrs := body[unixDiagMsgLen:]
+
+			for len(attrs) >= rtattrLen {
+				attrLen := binary.LittleEndian.Uint16(attrs[0:2])
+				attrType := binary.LittleEndian.Uint16(attrs[2:4])
+				if attrLen < rtattrLen || int(attrLen) > len(attrs) {
+					break
+				}
+
+				if attrType == unixDiagPeer && attrLen >= rtattrLen+4 {
+					peers[ino] = int(binary.LittleEndian.Uint32(attrs[rtattrLen : rtattrLen+4]))
+				}
+
+				// rtattr payloads are padded up to 4-byte alignment
+				advance := (int(attrLen) + 3) &^ 3
+				attrs = attrs[advance:]
+			}
+		}
+
+		buf = buf[msgLen:]
+	}
+
+	return false, nil
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package host
+
+import "fmt"
+
+// unixPeerINodes is unsupported outside linux, since NETLINK_SOCK_DIAG is
+// a Linux-only kernel interface.
+func unixPeerINodes() (map[int]int, error) {
+	return nil, fmt.Errorf("unix socket peer resolution is not supported on this platform")
+}
@@ -0,0 +1,117 @@
+// Package trace adds an optional, eBPF-backed view of live TCP behaviour
+// (retransmits, drops, send-to-ack latency) on top of the static
+// /proc/net/tcp snapshots host.CollectNetwork already provides. It is
+// only implemented on linux+cgo builds; elsewhere, and when the running
+// process lacks CAP_BPF, it degrades to "unavailable" so callers can fall
+// back to procfs-only mode.
+package trace
+
+import "sync"
+
+// ConnectionEvent is a single causally-attributed event observed for a
+// monitored TCP connection, keyed by the same socket inode used in
+// host.NetworkINodes.
+type ConnectionEvent struct {
+	INode      int
+	Kind       EventKind
+	DropReason string
+	LatencyNs  int64
+}
+
+// EventKind identifies what a ConnectionEvent represents.
+type EventKind int
+
+const (
+	Retransmit EventKind = iota
+	Drop
+	Latency
+)
+
+// ConnectionStats aggregates the events observed for a single connection
+// (keyed by socket inode) since the tracer started, for consumers such as
+// the Prometheus exporter that want per-connection counters rather than a
+// raw event stream.
+type ConnectionStats struct {
+	Retransmits    int64
+	Drops          int64
+	LastDropReason string
+	LatencyNs      int64 // most recent send-to-ack sample
+}
+
+type statsStore struct {
+	mu      sync.Mutex
+	byINode map[int]ConnectionStats
+}
+
+func newStatsStore() *statsStore {
+	return &statsStore{byINode: make(map[int]ConnectionStats)}
+}
+
+func (s *statsStore) apply(ev ConnectionEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.byINode[ev.INode]
+	switch ev.Kind {
+	case Retransmit:
+		stats.Retransmits++
+	case Drop:
+		stats.Drops++
+		stats.LastDropReason = ev.DropReason
+	case Latency:
+		stats.LatencyNs = ev.LatencyNs
+	}
+	s.byINode[ev.INode] = stats
+}
+
+func (s *statsStore) snapshot() map[int]ConnectionStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[int]ConnectionStats, len(s.byINode))
+	for inode, stats := range s.byINode {
+		out[inode] = stats
+	}
+	return out
+}
+
+// Tracer attaches to kernel tracepoints for tcp_retransmit_skb, kfree_skb
+// and socket send/recv, and delivers the resulting events as they occur.
+type Tracer struct {
+	events chan ConnectionEvent
+	stats  *statsStore
+	closer func() error
+}
+
+// Events returns the channel ConnectionEvents are delivered on, for a tab
+// or other consumer that wants to react to events as they happen. It is
+// closed when the Tracer is closed. Delivery is best-effort: a consumer
+// that isn't keeping up won't block the tracer, since Stats() always
+// reflects every event regardless of whether it was also delivered here.
+func (t *Tracer) Events() <-chan ConnectionEvent {
+	return t.events
+}
+
+// Stats returns a snapshot of the per-connection counters accumulated so
+// far, keyed by socket inode.
+func (t *Tracer) Stats() map[int]ConnectionStats {
+	return t.stats.snapshot()
+}
+
+// emit records ev in the aggregate stats and forwards it to Events() on a
+// best-effort basis, so a slow or absent consumer never blocks tracing.
+func (t *Tracer) emit(ev ConnectionEvent) {
+	t.stats.apply(ev)
+
+	select {
+	case t.events <- ev:
+	default:
+	}
+}
+
+// Close detaches the tracepoints and releases kernel resources.
+func (t *Tracer) Close() error {
+	err := t.closer()
+	close(t.events)
+	return err
+}
@@ -0,0 +1,228 @@
+//go:build linux && cgo
+
+package trace
+
+/*
+#cgo LDFLAGS: -lbpf
+#include <bpf/libbpf.h>
+#include <bpf/bpf.h>
+#include <stdint.h>
+
+struct uro_event {
+	uint64_t inode;
+	uint32_t kind;
+	uint32_t drop_reason;
+	uint64_t latency_ns;
+};
+
+extern void uroHandleEvent(uint64_t id, struct uro_event *ev);
+
+static int uro_ringbuf_cb(void *ctx, void *data, size_t size) {
+	if (size < sizeof(struct uro_event)) {
+		return 0;
+	}
+	uroHandleEvent((uint64_t)(uintptr_t)ctx, (struct uro_event *)data);
+	return 0;
+}
+
+static struct bpf_object *uro_load_probes(const char *path) {
+	struct bpf_object *obj = bpf_object__open_file(path, NULL);
+	if (!obj) {
+		return NULL;
+	}
+	if (bpf_object__load(obj)) {
+		bpf_object__close(obj);
+		return NULL;
+	}
+	return obj;
+}
+
+static int uro_attach_tracepoints(struct bpf_object *obj) {
+	struct bpf_program *prog;
+	bpf_object__for_each_program(prog, obj) {
+		if (libbpf_get_error(bpf_program__attach(prog))) {
+			return -1;
+		}
+	}
+	return 0;
+}
+
+static struct ring_buffer *uro_new_ringbuf(struct bpf_object *obj, uint64_t id) {
+	int fd = bpf_object__find_map_fd_by_name(obj, "events");
+	if (fd < 0) {
+		return NULL;
+	}
+	return ring_buffer__new(fd, uro_ringbuf_cb, (void *)(uintptr_t)id, NULL);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// probesObjectPath is where the compiled BPF object (tracepoints for
+// tcp_retransmit_skb, kfree_skb and socket send/recv, reporting through a
+// BPF_MAP_TYPE_RINGBUF map named "events") is installed alongside the
+// uroboros binary.
+const probesObjectPath = "/usr/share/uroboros/probes.bpf.o"
+
+const capBPF = 39 // CAP_BPF, see capability(7)
+
+// dropReasons maps the skb drop reason codes the probes report (mirroring
+// enum skb_drop_reason in the kernel) to human-readable strings.
+var dropReasons = map[uint32]string{
+	0: "unknown",
+	1: "no_socket",
+	2: "tcp_invalid_sequence",
+	3: "tcp_reset",
+}
+
+var (
+	tracersMu sync.Mutex
+	tracers   = map[uint64]*Tracer{}
+	nextID    uint64
+)
+
+func registerTracer(t *Tracer) uint64 {
+	tracersMu.Lock()
+	defer tracersMu.Unlock()
+	nextID++
+	tracers[nextID] = t
+	return nextID
+}
+
+func unregisterTracer(id uint64) {
+	tracersMu.Lock()
+	defer tracersMu.Unlock()
+	delete(tracers, id)
+}
+
+//export uroHandleEvent
+func uroHandleEvent(id C.uint64_t, cev *C.struct_uro_event) {
+	tracersMu.Lock()
+	t := tracers[uint64(id)]
+	tracersMu.Unlock()
+	if t == nil {
+		return
+	}
+
+	ev := ConnectionEvent{
+		INode:     int(cev.inode),
+		Kind:      EventKind(cev.kind),
+		LatencyNs: int64(cev.latency_ns),
+	}
+	if ev.Kind == Drop {
+		ev.DropReason = dropReasons[uint32(cev.drop_reason)]
+	}
+
+	t.emit(ev)
+}
+
+// Available reports whether eBPF tracing can be used: a linux+cgo build,
+// the compiled probe object present on disk, and CAP_BPF (or root) in the
+// running process's effective capability set.
+func Available() bool {
+	if _, err := os.Stat(probesObjectPath); err != nil {
+		return false
+	}
+	return hasCapBPF()
+}
+
+// Start attaches the tracepoints, opens the ring buffer they report
+// through, and begins delivering ConnectionEvents. Callers should check
+// Available() first; Start returns an error if the required capabilities
+// or the compiled probe object are missing.
+func Start() (*Tracer, error) {
+	if !Available() {
+		return nil, fmt.Errorf("eBPF tracing unavailable: missing CAP_BPF, unsupported kernel, or probes.bpf.o not installed")
+	}
+
+	cpath := C.CString(probesObjectPath)
+	defer C.free(unsafe.Pointer(cpath))
+
+	obj := C.uro_load_probes(cpath)
+	if obj == nil {
+		return nil, fmt.Errorf("could not load eBPF probes from %s", probesObjectPath)
+	}
+
+	if C.uro_attach_tracepoints(obj) != 0 {
+		C.bpf_object__close(obj)
+		return nil, fmt.Errorf("could not attach eBPF tracepoints")
+	}
+
+	t := &Tracer{
+		events: make(chan ConnectionEvent, 256),
+		stats:  newStatsStore(),
+	}
+
+	id := registerTracer(t)
+
+	rb := C.uro_new_ringbuf(obj, C.uint64_t(id))
+	if rb == nil {
+		unregisterTracer(id)
+		C.bpf_object__close(obj)
+		return nil, fmt.Errorf("could not open eBPF ring buffer")
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	t.closer = func() error {
+		close(done)
+		<-stopped // ring_buffer__poll may still be running; wait for it to return before freeing rb
+		C.ring_buffer__free(rb)
+		unregisterTracer(id)
+		C.bpf_object__close(obj)
+		return nil
+	}
+
+	go pollRingBuffer(rb, done, stopped)
+
+	return t, nil
+}
+
+func pollRingBuffer(rb *C.struct_ring_buffer, done <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+	for {
+		select {
+		case <-done:
+			return
+		default:
+			C.ring_buffer__poll(rb, 100) // ms
+		}
+	}
+}
+
+// hasCapBPF reads /proc/self/status to check whether CAP_BPF is set in
+// the process's effective capability mask.
+func hasCapBPF() bool {
+	data, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return false
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "CapEff:") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return false
+		}
+
+		mask, err := strconv.ParseUint(fields[1], 16, 64)
+		if err != nil {
+			return false
+		}
+
+		return mask&(1<<capBPF) != 0
+	}
+
+	return false
+}
@@ -0,0 +1,16 @@
+//go:build !(linux && cgo)
+
+package trace
+
+import "fmt"
+
+// Available is always false outside linux+cgo builds.
+func Available() bool {
+	return false
+}
+
+// Start always fails outside linux+cgo builds; callers fall back to the
+// existing procfs-only snapshot.
+func Start() (*Tracer, error) {
+	return nil, fmt.Errorf("eBPF tracing requires a linux+cgo build")
+}